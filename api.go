@@ -1,15 +1,18 @@
 package gotel
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"html/template"
-	"io/ioutil"
 	"log"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/etsangsplk/gotel/metrics"
+	"github.com/etsangsplk/gotel/notifier"
 )
 
 // Response will hold a response sent back to the caller
@@ -24,8 +27,10 @@ type badGuest struct {
 type node struct {
 	ID            int
 	IPAddress     string
-	NodeID        int
+	NodeID        string
 	IsCoordinator bool
+	Reachable     bool
+	Breaker       string
 }
 
 var validTimeUnits = map[string]int{"seconds": 1, "minutes": 1, "hours": 1}
@@ -36,12 +41,12 @@ func writeError(w http.ResponseWriter, e interface{}) {
 	if bytes, err := json.Marshal(e); err != nil {
 		_, err = w.Write([]byte("Could not encode error"))
 		if err != nil {
-			l.err("Could not encode error [%v]", err)
+			l.Err("Could not encode error [%v]", err)
 		}
 	} else {
 		_, err = w.Write(bytes)
 		if err != nil {
-			l.err("Could not write error [%v]", err)
+			l.Err("Could not write error [%v]", err)
 		}
 	}
 }
@@ -49,40 +54,56 @@ func writeError(w http.ResponseWriter, e interface{}) {
 func writeResponse(w http.ResponseWriter, e interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	if bytes, err := json.Marshal(e); err != nil {
-		l.err("Could not encode response [%v]", err)
+		l.Err("Could not encode response [%v]", err)
 		writeError(w, "Could not encode response")
 	} else {
 		_, err = w.Write(bytes)
 		if err != nil {
-			l.err("Could not write response [%v]", err)
+			l.Err("Could not write response [%v]", err)
 		}
 		return
 	}
 }
 
 func (ge *Endpoint) makeReservation(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
 	res := new(reservation)
 	decoder := json.NewDecoder(req.Body)
 	err := decoder.Decode(&res)
 	if err != nil {
-		l.err("Unable to accept reservation")
+		ge.log().Err("Unable to accept reservation")
 	}
+	ge.storeAndRespondReservation(w, req, res, start)
+}
 
-	err = validateReservation(res)
+// storeAndRespondReservation validates and persists res, writing the
+// standard OK/error response. Shared by the JSON-body /reservation handler
+// and the /reservation/:app/:component path shortcut.
+func (ge *Endpoint) storeAndRespondReservation(w http.ResponseWriter, req *http.Request, res *reservation, start time.Time) {
+	fields := func(extra ...any) []any {
+		return append(requestFields(req, res.App, res.Component, res.Owner, start), extra...)
+	}
+
+	err := validateReservation(res)
+	if err == nil {
+		_, err = notifier.Build(res.NotifyChannels)
+	}
 	if err != nil {
-		l.warn("Invalid reservations [%v]", res)
+		ge.log().Structured(req.Context(), slog.LevelWarn, "invalid reservation", fields(slog.Any("error", err))...)
 		writeError(w, fmt.Sprintf("Unable to store reservation, validation failure [%v]", err))
 		return
 	}
 
-	l.info("%v", res)
-
-	_, err = storeReservation(ge.Db, res)
+	err = traced(req.Context(), "applyReservation", func(ctx context.Context) error {
+		return ge.applyReservation(res)
+	})
 	if err != nil {
-		l.err("Unable to store reservation %v", res)
+		ge.log().Structured(req.Context(), slog.LevelError, "unable to store reservation", fields(slog.Any("error", err))...)
 		writeError(w, "Unable to store reservation")
 		return
 	}
+	metrics.ReservationsTotal.Inc()
+	ge.log().Structured(req.Context(), slog.LevelInfo, "reservation stored", fields()...)
 	writeResponse(w, "OK")
 }
 
@@ -92,8 +113,15 @@ func (ge *Endpoint) getReservations() ([]reservation, error) {
 	if err != nil {
 		return nil, err
 	}
-	reservations := []reservation{}
 	defer rows.Close()
+
+	schedules, err := buildScheduleIndex(ge.Db)
+	if err != nil {
+		ge.log().Err("Unable to load snooze schedules [%v]", err)
+		schedules = scheduleIndex{}
+	}
+
+	reservations := []reservation{}
 	for rows.Next() {
 		var alertMessage sql.NullString
 		res := reservation{}
@@ -105,56 +133,119 @@ func (ge *Endpoint) getReservations() ([]reservation, error) {
 		lastCheckin := time.Unix(res.LastCheckin, 0)
 		res.TimeSinceLastCheckin = RelTime(lastCheckin, time.Now(), "ago", "")
 		res.LastCheckinStr = lastCheckin.Format(time.RFC1123)
-		if FailsSLA(res) {
-			res.FailingSLA = true
-		} else {
-			res.FailingSLA = false
-		}
+		res.FailingSLA = FailsSLA(res) && !isSnoozedByIndex(schedules, res.App, res.Component, time.Now())
 		if (!alertMessage.Valid) || (alertMessage.String == "") {
 			res.AlertMessage = alertMessage.String
 		}
 		reservations = append(reservations, res)
 	}
+	metrics.ReservationsCurrent.Set(float64(len(reservations)))
 	return reservations, nil
 }
 
-func (ge *Endpoint) getNodes() ([]node, error) {
+func (ge *Endpoint) getReservation(app, component string) (reservation, error) {
+	query := "SELECT id, app, component, owner, notify, alert_msg, frequency, time_units, last_checkin_timestamp, num_checkins, notify_channels FROM reservations WHERE app = ? AND component = ?"
+	row := ge.Db.QueryRow(query, app, component)
 
-	query := "SELECT id, ip_address, node_id FROM nodes ORDER BY id;"
-	rows, err := ge.Db.Query(query)
+	var alertMessage sql.NullString
+	var channelsJSON sql.NullString
+	res := reservation{}
+	err := row.Scan(&res.JobID, &res.App, &res.Component, &res.Owner, &res.Notify, &alertMessage, &res.Frequency,
+		&res.TimeUnits, &res.LastCheckin, &res.NumCheckins, &channelsJSON)
 	if err != nil {
-		return nil, err
+		return res, err
 	}
-	nodes := []node{}
-	defer rows.Close()
-	for rows.Next() {
-		res := node{IsCoordinator: false}
-		err = rows.Scan(&res.ID, &res.IPAddress, &res.NodeID)
-		if err != nil {
-			return nil, err
+	if channelsJSON.Valid && channelsJSON.String != "" {
+		if err := json.Unmarshal([]byte(channelsJSON.String), &res.NotifyChannels); err != nil {
+			return res, fmt.Errorf("unable to parse notify_channels: %v", err)
 		}
+	}
+	return res, nil
+}
 
-		resp, err := http.Get(fmt.Sprintf("http://%s:8080/is-coordinator", res.IPAddress))
-		if err != nil {
-			l.warn("Unable to contact node [%s] assuming offline", res.IPAddress)
-			continue
-		}
-		defer resp.Body.Close()
+// doNotifyTest sends a synthetic alert through a reservation's configured
+// notification channels so operators can validate the wiring without
+// waiting for a real SLA miss.
+func (ge *Endpoint) doNotifyTest(w http.ResponseWriter, req *http.Request) {
+	app := req.URL.Query().Get("app")
+	component := req.URL.Query().Get("component")
+	if app == "" || component == "" {
+		writeError(w, "app and component query params are required")
+		return
+	}
 
-		if resp.StatusCode != 200 {
-			l.warn("Didn't get a 200OK reply back from ip [%s]", res.IPAddress)
-			continue
-		}
+	res, err := ge.getReservation(app, component)
+	if err != nil {
+		ge.log().Warn("No reservation found for notify test %s/%s [%v]", app, component, err)
+		writeError(w, fmt.Sprintf("No reservation found for %s/%s", app, component))
+		return
+	}
 
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			l.warn("Unable to read node response")
-		}
-		if string(body) == "true" {
-			res.IsCoordinator = true
+	notifiers, err := notifier.Build(res.NotifyChannels)
+	if err != nil {
+		ge.log().Err("Unable to build notifiers for %s/%s [%v]", app, component, err)
+		writeError(w, fmt.Sprintf("Unable to build notifiers: %v", err))
+		return
+	}
+
+	alert := notifier.Alert{
+		App:             res.App,
+		Component:       res.Component,
+		Owner:           res.Owner,
+		LastCheckin:     time.Unix(res.LastCheckin, 0),
+		DurationOverdue: time.Since(time.Unix(res.LastCheckin, 0)),
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 10*time.Second)
+	defer cancel()
+	for _, result := range notifier.FanOut(ctx, notifiers, alert) {
+		if result.Err != nil {
+			ge.log().Warn("Test notification failed for %s/%s [%v]", app, component, result.Err)
 		}
+	}
 
-		nodes = append(nodes, res)
+	r := Response{"success": true, "message": fmt.Sprintf("Sent test alert through %d channel(s) for %s/%s", len(notifiers), app, component)}
+	writeResponse(w, r)
+}
+
+// getNodes reports the cluster's Raft voter configuration instead of
+// HTTP-polling "/is-coordinator" on every peer: membership and leadership
+// are now facts the Raft log agrees on, not something each page load has
+// to rediscover.
+// Raft membership doesn't tell us whether a peer's own HTTP API is
+// actually answering requests, so the /nodes view still probes each
+// member directly; getNodes does so with a deadline, a bounded worker
+// pool, and a circuit breaker per peer so one hung node can't stall the
+// page or leak a goroutine per load.
+func (ge *Endpoint) getNodes() ([]node, error) {
+	if ge.Cluster == nil {
+		return []node{}, nil
+	}
+	servers, err := ge.Cluster.Servers()
+	if err != nil {
+		return nil, err
+	}
+	leader := ge.Cluster.Leader()
+
+	addrs := make([]string, len(servers))
+	for i, s := range servers {
+		addrs[i] = s.Address
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	health := ge.probePeers(ctx, addrs)
+
+	nodes := make([]node, 0, len(servers))
+	for i, s := range servers {
+		h := health[s.Address]
+		nodes = append(nodes, node{
+			ID:            i,
+			IPAddress:     s.Address,
+			NodeID:        s.ID,
+			IsCoordinator: s.Address == leader,
+			Reachable:     h.Reachable,
+			Breaker:       h.Breaker,
+		})
 	}
 	return nodes, nil
 }
@@ -179,81 +270,133 @@ func (ge *Endpoint) getBadGuests() ([]badGuest, error) {
 }
 
 func (ge *Endpoint) listReservations(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	fields := func(extra ...any) []any {
+		return append(requestFields(req, "", "", "", start), extra...)
+	}
+
+	if req.URL.Query().Get("consistency") == "strong" && ge.Cluster != nil {
+		if err := ge.Cluster.Barrier(5 * time.Second); err != nil {
+			ge.log().Structured(req.Context(), slog.LevelError, "unable to perform strong-consistency barrier read", fields(slog.Any("error", err))...)
+			writeError(w, "Unable to perform strong-consistency read")
+			return
+		}
+	}
+
 	reservations, err := ge.getReservations()
 	if err != nil {
-		l.err("Unable to list reservations [%v]", err)
+		ge.log().Structured(req.Context(), slog.LevelError, "unable to list reservations", fields(slog.Any("error", err))...)
 		r := Response{"success": false, "message": "Unable to list reservations"}
 		writeResponse(w, r)
 		return
 	}
+	ge.log().Structured(req.Context(), slog.LevelInfo, "listed reservations", fields(slog.Int("count", len(reservations)))...)
 	result := Response{"success": true, "result": reservations}
 	writeResponse(w, result)
-	return
 }
 
 func (ge *Endpoint) doCheckin(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
 	c := new(checkin)
 	decoder := json.NewDecoder(req.Body)
 	err := decoder.Decode(&c)
 	if err != nil {
-		l.err("Unable to accept checkin for %v", c)
+		ge.log().Err("Unable to accept checkin for %v", c)
 		r := Response{"success": false, "message": "Unable to checkin: " + c.App}
 		writeResponse(w, r)
 		return
 	}
+	ge.storeAndRespondCheckin(w, req, c, start)
+}
 
+// storeAndRespondCheckin persists a checkin and its housekeeping record,
+// writing the standard success/error response. Shared by the JSON-body
+// /checkin handler and the /checkin/:app/:component path shortcut.
+func (ge *Endpoint) storeAndRespondCheckin(w http.ResponseWriter, req *http.Request, c *checkin, start time.Time) {
+	fields := func(extra ...any) []any {
+		return append(requestFields(req, c.App, c.Component, "", start), extra...)
+	}
 	now := time.Now().UTC().Unix()
 
-	_, err = storeCheckin(ge.Db, *c, now)
+	err := traced(req.Context(), "applyCheckin", func(ctx context.Context) error {
+		return ge.applyCheckin(c, now)
+	})
 	if err != nil {
-		l.err("Unable to save checkin for %v", c)
+		ge.log().Structured(req.Context(), slog.LevelError, "unable to save checkin", fields(slog.Any("error", err))...)
 		r := Response{"success": false, "message": "Unable to save checkin: " + c.App}
 		writeResponse(w, r)
 		return
 	}
+	metrics.CheckinsTotal.WithLabelValues(c.App, c.Component).Inc()
+	ge.resolveNotifications(req.Context(), c.App, c.Component)
+	ge.log().Structured(req.Context(), slog.LevelInfo, "checked in", fields()...)
+	r := Response{"success": true, "message": "Application checked in: " + c.App}
+	writeResponse(w, r)
+}
 
-	_, err = logHouseKeeping(ge.Db, *c, now)
+// resolveNotifications closes out any previously-triggered incident for
+// app/component now that its owner has checked back in, through whichever
+// of its configured channels support an explicit resolve step (see
+// notifier.Resolver). A reservation lookup failure just means there's
+// nothing to resolve, not a checkin failure, so it's logged and swallowed
+// rather than surfaced to the caller.
+func (ge *Endpoint) resolveNotifications(ctx context.Context, app, component string) {
+	res, err := ge.getReservation(app, component)
 	if err != nil {
-		l.err("Unable to save checkin for %v", c)
-		r := Response{"success": false, "message": "Unable to save checkin: " + c.App}
-		writeResponse(w, r)
+		ge.log().Info("No reservation to resolve notifications for %s/%s [%v]", app, component, err)
 		return
 	}
-	l.info("app [%s] component [%s] checked in %v", c.App, c.Component, time.Now())
-	r := Response{"success": true, "message": "Application checked in: " + c.App}
-	writeResponse(w, r)
+	notifiers, err := notifier.Build(res.NotifyChannels)
+	if err != nil {
+		ge.log().Err("Unable to build notifiers for %s/%s [%v]", app, component, err)
+		return
+	}
+
+	alert := notifier.Alert{App: res.App, Component: res.Component, Owner: res.Owner}
+	resolveCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for _, result := range notifier.ResolveAll(resolveCtx, notifiers, alert) {
+		if result.Err != nil {
+			ge.log().Warn("Resolve notification failed for %s/%s [%v]", app, component, result.Err)
+		}
+	}
 }
 
 // used when you know your service will be offline for a bit and you want to pause alerts
 func (ge *Endpoint) doSnooze(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
 	p := new(snooze)
 	decoder := json.NewDecoder(req.Body)
 	err := decoder.Decode(&p)
 	if err != nil {
-		l.err("Unable to accept snooze for %v", p)
+		ge.log().Err("Unable to accept snooze for %v", p)
 		r := Response{"success": false, "message": "Unable to snooze: " + p.App}
 		writeResponse(w, r)
 		return
 	}
+	fields := func(extra ...any) []any {
+		return append(requestFields(req, p.App, p.Component, "", start), extra...)
+	}
 
 	err = validateSnooze(p)
 	if err != nil {
-		l.warn("Invalid reservations [%q]", p)
+		ge.log().Structured(req.Context(), slog.LevelWarn, "invalid snooze", fields(slog.Any("error", err))...)
 		writeError(w, fmt.Sprintf("Unable to store snooze, validation failure [%v]", err))
 		return
 	}
 
-	_, err = storeSnooze(ge.Db, p)
+	err = ge.applySnooze(p)
 	if err != nil {
-		l.err("Unable to save snooze for %v", p)
+		ge.log().Structured(req.Context(), slog.LevelError, "unable to save snooze", fields(slog.Any("error", err))...)
 		r := Response{"success": false, "message": "Unable to save snooze: " + p.App}
 		writeResponse(w, r)
 		return
 	}
 
+	metrics.SnoozesTotal.Inc()
+	ge.log().Structured(req.Context(), slog.LevelInfo, "snooze stored", fields()...)
 	r := Response{"success": true, "message": "Application alerting paused: " + p.App}
 	writeResponse(w, r)
-
 }
 
 func validateSnooze(snooze *snooze) error {
@@ -269,28 +412,87 @@ func validateSnooze(snooze *snooze) error {
 
 // used when you know your service will be offline for a bit and you want to pause alerts
 func (ge *Endpoint) doCheckOut(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
 	p := new(checkOut)
 	decoder := json.NewDecoder(req.Body)
 	err := decoder.Decode(&p)
 	if err != nil {
-		l.err("Unable to accept checkout for %v error [%s]", p, err)
+		ge.log().Err("Unable to accept checkout for %v error [%s]", p, err)
 		r := Response{"success": false, "message": "Unable to checkout: " + p.App}
 		writeResponse(w, r)
 		return
 	}
-	_, err = storeCheckOut(ge.Db, p)
+	fields := func(extra ...any) []any {
+		return append(requestFields(req, p.App, p.Component, "", start), extra...)
+	}
+
+	err = ge.applyCheckout(p)
 	if err != nil {
-		l.err("Unable to save checkout for %v", p)
+		ge.log().Structured(req.Context(), slog.LevelError, "unable to save checkout", fields(slog.Any("error", err))...)
 		r := Response{"success": false, "message": "Unable to save checkout: " + p.App}
 		writeResponse(w, r)
 		return
 	}
+	ge.log().Structured(req.Context(), slog.LevelInfo, "checked out", fields()...)
 	r := Response{"success": true, "message": fmt.Sprintf("Application Removed [%s/%s] ", p.App, p.Component)}
 	writeResponse(w, r)
 }
 
+// isCoordinator reports whether this node is the current Raft leader.
+// coordinator status is now a fact the cluster agrees on via leader
+// election rather than a value this node declares about itself. A
+// Cluster-less Endpoint (still supported, e.g. in tests) has no leader to
+// ask, so it reports false instead of panicking.
 func (ge *Endpoint) isCoordinator(w http.ResponseWriter, req *http.Request) {
-	writeResponse(w, coordinator)
+	if ge.Cluster == nil {
+		metrics.ClusterIsCoordinator.Set(0)
+		writeResponse(w, false)
+		return
+	}
+	isLeader := ge.Cluster.IsLeader()
+	if isLeader {
+		metrics.ClusterIsCoordinator.Set(1)
+	} else {
+		metrics.ClusterIsCoordinator.Set(0)
+	}
+	writeResponse(w, isLeader)
+}
+
+// doClusterJoin adds a new voter to the cluster. Only the leader can
+// accept this; non-leaders forward it like any other write.
+func (ge *Endpoint) doClusterJoin(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		NodeID string `json:"node_id"`
+		Addr   string `json:"addr"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, fmt.Sprintf("Unable to decode join request: %v", err))
+		return
+	}
+	if err := ge.Cluster.Join(body.NodeID, body.Addr); err != nil {
+		ge.log().Err("Unable to add voter %s at %s [%v]", body.NodeID, body.Addr, err)
+		writeError(w, fmt.Sprintf("Unable to join cluster: %v", err))
+		return
+	}
+	writeResponse(w, Response{"success": true, "message": fmt.Sprintf("Added node %s at %s", body.NodeID, body.Addr)})
+}
+
+// doClusterRemove removes a voter from the cluster, e.g. one being
+// decommissioned.
+func (ge *Endpoint) doClusterRemove(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		NodeID string `json:"node_id"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, fmt.Sprintf("Unable to decode remove request: %v", err))
+		return
+	}
+	if err := ge.Cluster.Remove(body.NodeID); err != nil {
+		ge.log().Err("Unable to remove node %s [%v]", body.NodeID, err)
+		writeError(w, fmt.Sprintf("Unable to remove node from cluster: %v", err))
+		return
+	}
+	writeResponse(w, Response{"success": true, "message": fmt.Sprintf("Removed node %s", body.NodeID)})
 }
 
 func validateReservation(res *reservation) error {
@@ -302,127 +504,19 @@ func validateReservation(res *reservation) error {
 	return nil
 }
 
-// InitAPI initializes the webservice on the specific port
+// InitAPI initializes the webservice on the specific port. It is a thin
+// wrapper around Handler, which embedders and tests should prefer so they
+// can exercise the API without binding a port.
 func (ge *Endpoint) InitAPI(port int, htmlPath string) {
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" {
-			r := Response{"success": true, "message": "A-OK!"}
-			writeResponse(w, r)
-		}
-	})
-
-	http.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" {
-			reservations, err := ge.getReservations()
-
-			if err != nil {
-				l.err(err.Error())
-				r := Response{"success": false, "message": "Unable to server views"}
-				writeResponse(w, r)
-			} else {
-				t, err := template.ParseFiles(htmlPath + "/public/view.html")
-				if err != nil {
-					l.err(err.Error())
-				} else {
-					err = t.Execute(w, &reservations)
-					if err != nil {
-						l.err(err.Error())
-					}
-				}
-
-			}
-
-		}
-	})
-
-	http.HandleFunc("/badguests", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" {
-			reservations, err := ge.getBadGuests()
-
-			if err != nil {
-				l.err(err.Error())
-				r := Response{"success": false, "message": "Unable to server views"}
-				writeResponse(w, r)
-			} else {
-				t, err := template.ParseFiles(htmlPath + "/public/badguests.html")
-				if err != nil {
-					l.err(err.Error())
-				} else {
-					err = t.Execute(w, &reservations)
-					if err != nil {
-						l.err(err.Error())
-					}
-				}
-			}
-		}
-	})
-
-	http.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" {
-			reservations, err := ge.getNodes()
-
-			if err != nil {
-				l.err(err.Error())
-				r := Response{"success": false, "message": "Unable to server views"}
-				writeResponse(w, r)
-			} else {
-				t, err := template.ParseFiles(htmlPath + "/public/nodes.html")
-				if err != nil {
-					l.err(err.Error())
-				} else {
-					err = t.Execute(w, &reservations)
-					if err != nil {
-						l.err(err.Error())
-					}
-				}
-			}
-		}
-	})
-
-	http.HandleFunc("/reservation", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" {
-			ge.listReservations(w, r)
-			return
-		} else if r.Method == "POST" {
-			ge.makeReservation(w, r)
-			return
-		}
-		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
-		return
-	})
-	http.HandleFunc("/checkin", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "POST" {
-			ge.doCheckin(w, r)
-			return
-		}
-		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
-		return
-	})
-	http.HandleFunc("/checkout", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "POST" {
-			ge.doCheckOut(w, r)
-			return
-		}
-		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
-		return
-	})
-	http.HandleFunc("/snooze", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "POST" {
-			ge.doSnooze(w, r)
-			return
-		}
-		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
-		return
-	})
-	http.HandleFunc("/is-coordinator", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" {
-			ge.isCoordinator(w, r)
-			return
-		}
-		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
-		return
-	})
-
-	server := http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
+	if err := ensureNotifyChannelsColumn(ge.Db); err != nil {
+		log.Panic(err)
+	}
+	if err := ensureUsersTable(ge.Db); err != nil {
+		log.Panic(err)
+	}
+	if err := ensureSnoozeSchedulesTable(ge.Db); err != nil {
+		log.Panic(err)
+	}
+	server := http.ListenAndServe(fmt.Sprintf(":%d", port), ge.Handler(htmlPath))
 	log.Panic(server)
 }
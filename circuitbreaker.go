@@ -0,0 +1,89 @@
+package gotel
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState is the lifecycle of a circuitBreaker.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker trips open after consecutive probe failures and stays
+// open for cooldown before allowing a single half-open probe through. It
+// guards peer probes so one hung or down peer doesn't keep eating a probe
+// slot and a timeout on every /nodes load.
+type circuitBreaker struct {
+	mu            sync.Mutex
+	state         breakerState
+	failureCount  int
+	failureThresh int
+	cooldown      time.Duration
+	openedAt      time.Time
+}
+
+func newCircuitBreaker(failureThresh int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{failureThresh: failureThresh, cooldown: cooldown}
+}
+
+// allow reports whether a probe should be attempted right now, and moves
+// an open breaker into half-open once its cooldown has elapsed. A half-open
+// breaker refuses further probes until the one it let through is recorded.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) >= b.cooldown {
+			b.state = breakerHalfOpen
+			return true
+		}
+		return false
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// recordSuccess closes the breaker and resets its failure count.
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = breakerClosed
+	b.failureCount = 0
+}
+
+// recordFailure counts a failed probe, opening the breaker once
+// failureThresh consecutive failures have been seen.
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failureCount++
+	if b.state == breakerHalfOpen || b.failureCount >= b.failureThresh {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *circuitBreaker) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
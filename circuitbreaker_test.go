@@ -0,0 +1,65 @@
+package gotel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if !b.allow() {
+			t.Fatalf("expected breaker to allow probe %d before threshold", i)
+		}
+		b.recordFailure()
+	}
+	if got := b.String(); got != "closed" {
+		t.Fatalf("breaker state = %q, want closed before threshold", got)
+	}
+
+	b.recordFailure()
+	if got := b.String(); got != "open" {
+		t.Fatalf("breaker state = %q, want open after %d consecutive failures", got, 3)
+	}
+	if b.allow() {
+		t.Fatal("expected open breaker to refuse a probe before cooldown elapses")
+	}
+}
+
+func TestCircuitBreaker_HalfOpenAfterCooldownThenCloses(t *testing.T) {
+	b := newCircuitBreaker(1, 10*time.Millisecond)
+
+	if !b.allow() {
+		t.Fatal("expected a fresh breaker to allow the first probe")
+	}
+	b.recordFailure()
+	if got := b.String(); got != "open" {
+		t.Fatalf("breaker state = %q, want open after threshold of 1", got)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("expected breaker to allow a probe once cooldown has elapsed")
+	}
+	if got := b.String(); got != "half-open" {
+		t.Fatalf("breaker state = %q, want half-open immediately after cooldown", got)
+	}
+
+	b.recordSuccess()
+	if got := b.String(); got != "closed" {
+		t.Fatalf("breaker state = %q, want closed after a successful half-open probe", got)
+	}
+}
+
+func TestCircuitBreaker_FailureDuringHalfOpenReopens(t *testing.T) {
+	b := newCircuitBreaker(1, 10*time.Millisecond)
+	b.recordFailure()
+	time.Sleep(15 * time.Millisecond)
+	b.allow() // moves closed->open to half-open
+
+	b.recordFailure()
+	if got := b.String(); got != "open" {
+		t.Fatalf("breaker state = %q, want open after a half-open probe fails", got)
+	}
+}
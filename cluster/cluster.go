@@ -0,0 +1,191 @@
+// Package cluster gives gotel a Raft-backed notion of which node is the
+// coordinator, replacing the HTTP-poll "is-coordinator" check on every
+// peer with hashicorp/raft leader election and a replicated log of
+// reservation CRUD, checkins, snoozes, and checkouts.
+package cluster
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb"
+)
+
+// Cluster wraps a raft.Raft instance and the FSM it drives.
+type Cluster struct {
+	raft *raft.Raft
+	fsm  *FSM
+}
+
+// Config describes how to stand up this node's Raft participation.
+type Config struct {
+	NodeID    string
+	RaftBind  string
+	RaftDir   string
+	Bootstrap bool
+}
+
+// New starts (or rejoins) a Raft node backed by db, applying committed log
+// entries with applies.
+//
+// FSM.Snapshot is a noop: the gotel package's SQL tables are the durable
+// state, but the FSM has no schema knowledge with which to serialize them
+// generically. So SnapshotThreshold is set to effectively never trigger,
+// which keeps Raft from ever compacting its log and handing a new voter an
+// empty snapshot in place of the history it needs. The tradeoff is that
+// Join (via /cluster/join) only replays entries committed from here
+// forward: every new node must be seeded with a copy of an existing node's
+// SQL database out-of-band before it joins, or its tables will stay empty
+// until the log catches it up.
+func New(cfg Config, db *sql.DB, applies map[Op]Apply) (*Cluster, error) {
+	if err := os.MkdirAll(cfg.RaftDir, 0755); err != nil {
+		return nil, fmt.Errorf("cluster: unable to create raft dir: %v", err)
+	}
+
+	raftCfg := raft.DefaultConfig()
+	raftCfg.LocalID = raft.ServerID(cfg.NodeID)
+	raftCfg.SnapshotThreshold = math.MaxUint64
+
+	addr, err := net.ResolveTCPAddr("tcp", cfg.RaftBind)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: unable to resolve raft bind address: %v", err)
+	}
+	transport, err := raft.NewTCPTransport(cfg.RaftBind, addr, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: unable to create raft transport: %v", err)
+	}
+
+	snapshots, err := raft.NewFileSnapshotStore(cfg.RaftDir, 2, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: unable to create snapshot store: %v", err)
+	}
+
+	boltStore, err := raftboltdb.NewBoltStore(filepath.Join(cfg.RaftDir, "raft.db"))
+	if err != nil {
+		return nil, fmt.Errorf("cluster: unable to create bolt store: %v", err)
+	}
+
+	fsm := NewFSM(db, applies)
+	r, err := raft.NewRaft(raftCfg, fsm, boltStore, boltStore, snapshots, transport)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: unable to create raft node: %v", err)
+	}
+
+	if cfg.Bootstrap {
+		configuration := raft.Configuration{
+			Servers: []raft.Server{
+				{ID: raftCfg.LocalID, Address: transport.LocalAddr()},
+			},
+		}
+		r.BootstrapCluster(configuration)
+	}
+
+	return &Cluster{raft: r, fsm: fsm}, nil
+}
+
+// IsLeader reports whether this node is currently the Raft leader, i.e. the
+// coordinator. It replaces the old package-level coordinator bool.
+func (c *Cluster) IsLeader() bool {
+	return c.raft.State() == raft.Leader
+}
+
+// Leader returns the address of the current leader, or "" if there is none
+// right now. Non-leader nodes use this to build the redirect Location for
+// forwarded writes.
+func (c *Cluster) Leader() string {
+	return string(c.raft.Leader())
+}
+
+// Server is the subset of raft.Server the gotel HTTP API surfaces on the
+// /nodes view.
+type Server struct {
+	ID      string
+	Address string
+}
+
+// Servers returns the cluster's current voter configuration.
+func (c *Cluster) Servers() ([]Server, error) {
+	future := c.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+	config := future.Configuration()
+	servers := make([]Server, 0, len(config.Servers))
+	for _, s := range config.Servers {
+		servers = append(servers, Server{ID: string(s.ID), Address: string(s.Address)})
+	}
+	return servers, nil
+}
+
+// apply marshals op/payload into a Command and submits it to the Raft log,
+// blocking until it commits (or timeout elapses).
+func (c *Cluster) apply(op Op, payload interface{}, timeout time.Duration) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	cmd := Command{Op: op, Payload: data}
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	return c.raft.Apply(b, timeout).Error()
+}
+
+// ApplyReservation replicates a reservation create/update.
+func (c *Cluster) ApplyReservation(res interface{}) error {
+	return c.apply(OpReserve, res, 10*time.Second)
+}
+
+// ApplyCheckin replicates a checkin.
+func (c *Cluster) ApplyCheckin(checkin interface{}) error {
+	return c.apply(OpCheckin, checkin, 10*time.Second)
+}
+
+// ApplySnooze replicates a snooze.
+func (c *Cluster) ApplySnooze(snooze interface{}) error {
+	return c.apply(OpSnooze, snooze, 10*time.Second)
+}
+
+// ApplyCheckout replicates a checkout.
+func (c *Cluster) ApplyCheckout(checkOut interface{}) error {
+	return c.apply(OpCheckout, checkOut, 10*time.Second)
+}
+
+// ApplySnoozeScheduleCreate replicates a recurring snooze schedule's
+// creation. The schedule's ID is assigned by the caller (not by this
+// node's local auto-increment) so it's identical on every replica once the
+// entry commits.
+func (c *Cluster) ApplySnoozeScheduleCreate(schedule interface{}) error {
+	return c.apply(OpSnoozeScheduleCreate, schedule, 10*time.Second)
+}
+
+// ApplySnoozeScheduleDelete replicates the cancellation of a recurring
+// snooze schedule by ID.
+func (c *Cluster) ApplySnoozeScheduleDelete(id interface{}) error {
+	return c.apply(OpSnoozeScheduleDelete, id, 10*time.Second)
+}
+
+// Barrier blocks until all log entries committed as of this call have been
+// applied to the local FSM, for /reservation?consistency=strong reads.
+func (c *Cluster) Barrier(timeout time.Duration) error {
+	return c.raft.Barrier(timeout).Error()
+}
+
+// Join adds nodeID at addr as a voter, forwarding to the leader is the
+// caller's responsibility (only the leader can accept this).
+func (c *Cluster) Join(nodeID, addr string) error {
+	return c.raft.AddVoter(raft.ServerID(nodeID), raft.ServerAddress(addr), 0, 10*time.Second).Error()
+}
+
+// Remove removes nodeID from the cluster's voter configuration.
+func (c *Cluster) Remove(nodeID string) error {
+	return c.raft.RemoveServer(raft.ServerID(nodeID), 0, 10*time.Second).Error()
+}
@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/raft"
+)
+
+// Op identifies the kind of mutation carried by a Command.
+type Op string
+
+// The set of mutations the FSM knows how to apply. Each corresponds to one
+// of the write endpoints in the gotel HTTP API.
+const (
+	OpReserve              Op = "reserve"
+	OpCheckin              Op = "checkin"
+	OpSnooze               Op = "snooze"
+	OpCheckout             Op = "checkout"
+	OpSnoozeScheduleCreate Op = "snooze_schedule_create"
+	OpSnoozeScheduleDelete Op = "snooze_schedule_delete"
+)
+
+// Command is a single Raft log entry: an operation plus its JSON-encoded
+// payload (a reservation, checkin, snooze, checkOut, or snooze schedule
+// create/delete).
+type Command struct {
+	Op      Op              `json:"op"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Apply is a function that persists one committed Command to the local SQL
+// tables. FSM is constructed with one Apply per Op; the cluster package
+// itself has no opinion on the row shapes involved, which keep living in
+// the gotel package's storeReservation/storeCheckin/storeSnooze/
+// storeCheckOut helpers.
+type Apply func(db *sql.DB, payload json.RawMessage) error
+
+// FSM applies committed Raft log entries to the local SQL database. It
+// replaces the direct storeX(db, ...) calls the HTTP handlers used to make:
+// once a Cluster exists, handlers call Cluster.Apply* and let the FSM make
+// the actual write once the entry commits.
+type FSM struct {
+	db      *sql.DB
+	applies map[Op]Apply
+}
+
+// NewFSM builds an FSM that applies committed entries against db using the
+// given per-op handlers.
+func NewFSM(db *sql.DB, applies map[Op]Apply) *FSM {
+	return &FSM{db: db, applies: applies}
+}
+
+// Apply implements raft.FSM. It is only ever called with entries the
+// cluster's own Apply* methods produced, so an unmarshal failure indicates
+// a bug rather than bad input.
+func (f *FSM) Apply(log *raft.Log) interface{} {
+	var cmd Command
+	if err := json.Unmarshal(log.Data, &cmd); err != nil {
+		return fmt.Errorf("fsm: unable to decode command: %v", err)
+	}
+	apply, ok := f.applies[cmd.Op]
+	if !ok {
+		return fmt.Errorf("fsm: no handler registered for op %q", cmd.Op)
+	}
+	if err := apply(f.db, cmd.Payload); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Snapshot implements raft.FSM. The SQL tables are the durable state, but
+// the FSM has no opinion on their shape (that lives in the gotel package's
+// storeX helpers), so it can't serialize them generically here. A noop
+// snapshot is only safe as long as Raft never uses it to catch a voter up:
+// New disables log compaction (see its SnapshotThreshold comment)
+// so this is never asked to produce one that actually needs to carry state.
+func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
+	return noopSnapshot{}, nil
+}
+
+// Restore implements raft.FSM. It never runs in practice: with compaction
+// disabled, Raft always replays the full log against a fresh node instead
+// of installing a snapshot. A new voter must still be seeded with a copy of
+// an existing node's SQL database out-of-band before joining (see New), or
+// replaying the log will give it only the writes made through the cluster,
+// not rows that predate it.
+func (f *FSM) Restore(rc io.ReadCloser) error {
+	return rc.Close()
+}
+
+type noopSnapshot struct{}
+
+func (noopSnapshot) Persist(sink raft.SnapshotSink) error { return sink.Close() }
+func (noopSnapshot) Release()                             {}
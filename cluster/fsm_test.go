@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/raft"
+)
+
+func logFor(t *testing.T, op Op, payload interface{}) *raft.Log {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	cmd := Command{Op: op, Payload: data}
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatalf("marshal command: %v", err)
+	}
+	return &raft.Log{Data: b}
+}
+
+func TestFSM_Apply_DispatchesToRegisteredOp(t *testing.T) {
+	type reservation struct {
+		App       string `json:"app"`
+		Component string `json:"component"`
+	}
+
+	var applied reservation
+	fsm := NewFSM(nil, map[Op]Apply{
+		OpReserve: func(db *sql.DB, payload json.RawMessage) error {
+			return json.Unmarshal(payload, &applied)
+		},
+	})
+
+	res := reservation{App: "billing", Component: "worker"}
+	if err := fsm.Apply(logFor(t, OpReserve, res)); err != nil {
+		t.Fatalf("Apply returned an error: %v", err)
+	}
+	if applied != res {
+		t.Fatalf("applied = %+v, want %+v", applied, res)
+	}
+}
+
+func TestFSM_Apply_UnknownOpReturnsError(t *testing.T) {
+	fsm := NewFSM(nil, map[Op]Apply{})
+	result := fsm.Apply(logFor(t, Op("unregistered"), struct{}{}))
+	err, ok := result.(error)
+	if !ok || err == nil {
+		t.Fatalf("Apply(unregistered op) = %v, want an error", result)
+	}
+}
+
+func TestFSM_Apply_MalformedLogReturnsError(t *testing.T) {
+	fsm := NewFSM(nil, map[Op]Apply{})
+	result := fsm.Apply(&raft.Log{Data: []byte("not json")})
+	err, ok := result.(error)
+	if !ok || err == nil {
+		t.Fatalf("Apply(malformed log) = %v, want an error", result)
+	}
+}
+
+func TestFSM_Apply_HandlerErrorPropagates(t *testing.T) {
+	wantErr := sql.ErrNoRows
+	fsm := NewFSM(nil, map[Op]Apply{
+		OpCheckin: func(db *sql.DB, payload json.RawMessage) error {
+			return wantErr
+		},
+	})
+
+	result := fsm.Apply(logFor(t, OpCheckin, struct{}{}))
+	if result != wantErr {
+		t.Fatalf("Apply result = %v, want %v", result, wantErr)
+	}
+}
@@ -0,0 +1,144 @@
+package gotel
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/etsangsplk/gotel/cluster"
+)
+
+// checkinCommand is the Raft log payload for cluster.OpCheckin: the checkin
+// plus the server-observed timestamp, so every replica applies the same
+// "now" instead of each FSM computing its own at apply time.
+type checkinCommand struct {
+	Checkin checkin `json:"checkin"`
+	Now     int64   `json:"now"`
+}
+
+// clusterApplies returns the Op handlers a Cluster applies to committed log
+// entries: exactly the writes the HTTP handlers used to make directly
+// against ge.Db before reservations, checkins, snoozes, and checkouts were
+// replicated, now run once per committed entry instead of once per request.
+func clusterApplies() map[cluster.Op]cluster.Apply {
+	return map[cluster.Op]cluster.Apply{
+		cluster.OpReserve: func(db *sql.DB, payload json.RawMessage) error {
+			var res reservation
+			if err := json.Unmarshal(payload, &res); err != nil {
+				return err
+			}
+			if _, err := storeReservation(db, &res); err != nil {
+				return err
+			}
+			return persistNotifyChannels(db, &res)
+		},
+		cluster.OpCheckin: func(db *sql.DB, payload json.RawMessage) error {
+			var cmd checkinCommand
+			if err := json.Unmarshal(payload, &cmd); err != nil {
+				return err
+			}
+			if _, err := storeCheckin(db, cmd.Checkin, cmd.Now); err != nil {
+				return err
+			}
+			_, err := logHouseKeeping(db, cmd.Checkin, cmd.Now)
+			return err
+		},
+		cluster.OpSnooze: func(db *sql.DB, payload json.RawMessage) error {
+			var p snooze
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return err
+			}
+			_, err := storeSnooze(db, &p)
+			return err
+		},
+		cluster.OpCheckout: func(db *sql.DB, payload json.RawMessage) error {
+			var p checkOut
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return err
+			}
+			_, err := storeCheckOut(db, &p)
+			return err
+		},
+		cluster.OpSnoozeScheduleCreate: func(db *sql.DB, payload json.RawMessage) error {
+			var s snoozeSchedule
+			if err := json.Unmarshal(payload, &s); err != nil {
+				return err
+			}
+			parsed, err := parseSnoozeSchedule(s)
+			if err != nil {
+				return err
+			}
+			return storeSnoozeSchedule(db, parsed)
+		},
+		cluster.OpSnoozeScheduleDelete: func(db *sql.DB, payload json.RawMessage) error {
+			var id int64
+			if err := json.Unmarshal(payload, &id); err != nil {
+				return err
+			}
+			return deleteSnoozeSchedule(db, id)
+		},
+	}
+}
+
+// applyReservation replicates res through the cluster's Raft log when one
+// is configured, falling back to a direct local write for a single-node
+// Endpoint (e.g. in tests) where there's no FSM to apply it.
+func (ge *Endpoint) applyReservation(res *reservation) error {
+	if ge.Cluster == nil {
+		if _, err := storeReservation(ge.Db, res); err != nil {
+			return err
+		}
+		return persistNotifyChannels(ge.Db, res)
+	}
+	return ge.Cluster.ApplyReservation(res)
+}
+
+// applyCheckin replicates c's checkin and housekeeping write as a single
+// committed entry, so a leader failover can never have recorded one without
+// the other.
+func (ge *Endpoint) applyCheckin(c *checkin, now int64) error {
+	if ge.Cluster == nil {
+		if _, err := storeCheckin(ge.Db, *c, now); err != nil {
+			return err
+		}
+		_, err := logHouseKeeping(ge.Db, *c, now)
+		return err
+	}
+	return ge.Cluster.ApplyCheckin(checkinCommand{Checkin: *c, Now: now})
+}
+
+// applySnooze replicates p through the cluster's Raft log.
+func (ge *Endpoint) applySnooze(p *snooze) error {
+	if ge.Cluster == nil {
+		_, err := storeSnooze(ge.Db, p)
+		return err
+	}
+	return ge.Cluster.ApplySnooze(p)
+}
+
+// applyCheckout replicates p through the cluster's Raft log.
+func (ge *Endpoint) applyCheckout(p *checkOut) error {
+	if ge.Cluster == nil {
+		_, err := storeCheckOut(ge.Db, p)
+		return err
+	}
+	return ge.Cluster.ApplyCheckout(p)
+}
+
+// applySnoozeScheduleCreate replicates parsed's creation through the
+// cluster's Raft log, so a failover doesn't leave the new leader's
+// snooze_schedules empty of a window a prior leader created.
+func (ge *Endpoint) applySnoozeScheduleCreate(parsed *parsedSchedule) error {
+	if ge.Cluster == nil {
+		return storeSnoozeSchedule(ge.Db, parsed)
+	}
+	return ge.Cluster.ApplySnoozeScheduleCreate(parsed.snoozeSchedule)
+}
+
+// applySnoozeScheduleDelete replicates the cancellation of schedule id
+// through the cluster's Raft log.
+func (ge *Endpoint) applySnoozeScheduleDelete(id int64) error {
+	if ge.Cluster == nil {
+		return deleteSnoozeSchedule(ge.Db, id)
+	}
+	return ge.Cluster.ApplySnoozeScheduleDelete(id)
+}
@@ -0,0 +1,94 @@
+// Package log wraps log/slog with printf-style Err/Warn/Info methods shaped
+// like the gotel package's older err/warn/info logger, so existing call
+// sites only needed the method renamed, while giving every log line structured fields, a choice of JSON or text
+// output, and room for per-request correlation IDs pulled from context.
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Format selects the slog.Handler a Logger is built with.
+type Format string
+
+// Supported output formats.
+const (
+	FormatJSON Format = "json"
+	FormatText Format = "text"
+)
+
+// Logger is a thin leveled wrapper around *slog.Logger.
+type Logger struct {
+	slog *slog.Logger
+}
+
+// Config controls how New builds a Logger.
+type Config struct {
+	Level  slog.Level
+	Format Format
+	Output io.Writer
+}
+
+// New builds a Logger from cfg, defaulting to info level, JSON output, and
+// os.Stderr when those fields are left zero.
+func New(cfg Config) *Logger {
+	out := cfg.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+
+	var handler slog.Handler
+	if cfg.Format == FormatText {
+		handler = slog.NewTextHandler(out, opts)
+	} else {
+		handler = slog.NewJSONHandler(out, opts)
+	}
+	return &Logger{slog: slog.New(handler)}
+}
+
+// Default returns a Logger at info level writing JSON to stderr, used
+// until an embedder calls Endpoint.WithLogger with something else.
+func Default() *Logger {
+	return New(Config{Level: slog.LevelInfo, Format: FormatJSON})
+}
+
+// FromSlog wraps an already-configured *slog.Logger, for embedders that
+// want gotel's log lines to go through a logger they built themselves.
+func FromSlog(sl *slog.Logger) *Logger {
+	return &Logger{slog: sl}
+}
+
+// With returns a Logger that always includes the given key/value pairs,
+// e.g. a request ID threaded through a single request's handler chain.
+func (l *Logger) With(args ...interface{}) *Logger {
+	return &Logger{slog: l.slog.With(args...)}
+}
+
+// Err logs at error level with a printf-style message, taking the same
+// (format, args...) as the older logger's err, so callers without
+// structured fields to report only needed the method renamed.
+func (l *Logger) Err(format string, args ...interface{}) {
+	l.slog.Error(fmt.Sprintf(format, args...))
+}
+
+// Warn logs at warn level with a printf-style message.
+func (l *Logger) Warn(format string, args ...interface{}) {
+	l.slog.Warn(fmt.Sprintf(format, args...))
+}
+
+// Info logs at info level with a printf-style message.
+func (l *Logger) Info(format string, args ...interface{}) {
+	l.slog.Info(fmt.Sprintf(format, args...))
+}
+
+// Structured logs msg with structured fields attached, for call sites that
+// have real fields to report (app, component, owner, request_id,
+// remote_addr, duration_ms) rather than a formatted sentence.
+func (l *Logger) Structured(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	l.slog.Log(ctx, level, msg, args...)
+}
@@ -0,0 +1,33 @@
+package gotel
+
+import (
+	"log/slog"
+
+	gotellog "github.com/etsangsplk/gotel/log"
+)
+
+// l is the process-wide default logger, used by call sites such as
+// writeError/writeResponse that have no *Endpoint in scope. Call sites that
+// do have one should log through ge.log() instead, so WithLogger on one
+// Endpoint can't repoint logging for every other Endpoint sharing the
+// process.
+var l = gotellog.Default()
+
+// WithLogger replaces the Endpoint's own logger with lg, returning ge for
+// chaining. Embedders that already run a slog.Logger (for ELK/Loki
+// shipping, say) can point gotel's log lines at it instead of the package
+// default, without affecting any other Endpoint embedded in the same
+// process.
+func (ge *Endpoint) WithLogger(lg *slog.Logger) *Endpoint {
+	ge.logger = gotellog.FromSlog(lg)
+	return ge
+}
+
+// log returns ge's own logger if WithLogger was called, falling back to
+// the process-wide default otherwise.
+func (ge *Endpoint) log() *gotellog.Logger {
+	if ge.logger != nil {
+		return ge.logger
+	}
+	return l
+}
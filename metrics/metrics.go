@@ -0,0 +1,56 @@
+// Package metrics exposes the Prometheus counters, histograms, and gauges
+// gotel's HTTP handlers and SLA evaluator report against.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// CheckinsTotal counts every checkin accepted, by app and component.
+	CheckinsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotel_checkins_total",
+		Help: "Total number of checkins accepted.",
+	}, []string{"app", "component"})
+
+	// ReservationsTotal counts every reservation created or updated.
+	ReservationsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gotel_reservations_total",
+		Help: "Total number of reservations created or updated.",
+	})
+
+	// SLAFailuresTotal counts every SLA breach observed, by app and
+	// component.
+	SLAFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotel_sla_failures_total",
+		Help: "Total number of SLA breaches observed.",
+	}, []string{"app", "component"})
+
+	// SnoozesTotal counts every snooze accepted.
+	SnoozesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gotel_snoozes_total",
+		Help: "Total number of snoozes accepted.",
+	})
+
+	// HTTPRequestDuration observes request latency by route, method, and
+	// status.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gotel_http_request_duration_seconds",
+		Help:    "HTTP request duration in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// ReservationsCurrent is the number of reservations currently tracked.
+	ReservationsCurrent = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gotel_reservations_current",
+		Help: "Number of reservations currently tracked.",
+	})
+
+	// ClusterIsCoordinator is 1 on the node that is currently the Raft
+	// leader/coordinator, 0 otherwise.
+	ClusterIsCoordinator = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gotel_cluster_is_coordinator",
+		Help: "1 if this node is the current cluster coordinator, 0 otherwise.",
+	})
+)
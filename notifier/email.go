@@ -0,0 +1,38 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+)
+
+// EmailNotifier delivers alerts over SMTP.
+type EmailNotifier struct {
+	SMTPAddr string
+	From     string
+	To       string
+}
+
+func newEmailNotifier(cfg map[string]string) (*EmailNotifier, error) {
+	e := &EmailNotifier{
+		SMTPAddr: cfg["smtp_addr"],
+		From:     cfg["from"],
+		To:       cfg["to"],
+	}
+	if e.SMTPAddr == "" || e.From == "" || e.To == "" {
+		return nil, fmt.Errorf("email channel requires smtp_addr, from, and to")
+	}
+	return e, nil
+}
+
+// Notify sends a plain text email describing the breach. ctx is unused
+// because net/smtp has no context-aware API, so neither ctx nor the
+// caller's retry loop can cut a slow SendMail short. FanOut runs each
+// channel in its own goroutine, so other channels are not held up, but
+// FanOut itself returns only once SendMail does.
+func (e *EmailNotifier) Notify(ctx context.Context, a Alert) error {
+	subject := fmt.Sprintf("Subject: gotel SLA breach: %s/%s\r\n", a.App, a.Component)
+	body := fmt.Sprintf("\r\n%s/%s has not checked in since %s (owner: %s, overdue by %s)\r\n",
+		a.App, a.Component, a.LastCheckin.Format("Mon Jan 2 15:04:05 MST 2006"), a.Owner, a.DurationOverdue)
+	return smtp.SendMail(e.SMTPAddr, nil, e.From, []string{e.To}, []byte(subject+body))
+}
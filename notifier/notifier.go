@@ -0,0 +1,145 @@
+// Package notifier delivers SLA alerts to the channels an owner configured
+// on their reservation: email, generic webhooks, Slack, and PagerDuty.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Alert describes a single SLA breach (or its resolution) to be delivered
+// to a notification channel.
+type Alert struct {
+	App             string
+	Component       string
+	Owner           string
+	LastCheckin     time.Time
+	DurationOverdue time.Duration
+}
+
+// dedupKey identifies an alert for the purposes of PagerDuty trigger/resolve
+// deduplication.
+func (a Alert) dedupKey() string {
+	return a.App + "/" + a.Component
+}
+
+// Notifier delivers an Alert to a single destination.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// Resolver is implemented by notifiers with an explicit close-out step
+// distinct from Notify's breach alert, currently just PagerDuty's
+// trigger/resolve model. Email, webhook, and Slack have no such notion, so
+// they don't implement it.
+type Resolver interface {
+	Resolve(ctx context.Context, a Alert) error
+}
+
+// Channel is the persisted, user-facing description of a single
+// notification destination. It is stored on a reservation's
+// notify_channels array and turned into a concrete Notifier with Build.
+type Channel struct {
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config"`
+}
+
+// Build turns a reservation's configured channels into concrete Notifiers,
+// skipping nothing: a bad channel configuration is an error so operators
+// find out at reservation time rather than when the first alert fails to
+// send.
+func Build(channels []Channel) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(channels))
+	for _, c := range channels {
+		n, err := build(c)
+		if err != nil {
+			return nil, fmt.Errorf("notify_channels: %v", err)
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+func build(c Channel) (Notifier, error) {
+	switch c.Type {
+	case "email":
+		return newEmailNotifier(c.Config)
+	case "webhook":
+		return newWebhookNotifier(c.Config)
+	case "slack":
+		return newSlackNotifier(c.Config)
+	case "pagerduty":
+		return newPagerDutyNotifier(c.Config)
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", c.Type)
+	}
+}
+
+// Result pairs a Notifier's delivery attempt with the error it returned, so
+// callers can report per-channel failures rather than a single combined one.
+type Result struct {
+	Notifier Notifier
+	Err      error
+}
+
+// FanOut delivers alert to every notifier concurrently, retrying each one
+// with a short backoff before giving up. It always returns one Result per
+// notifier, in the same order they were passed in.
+func FanOut(ctx context.Context, notifiers []Notifier, a Alert) []Result {
+	results := make([]Result, len(notifiers))
+	done := make(chan struct{})
+	for i, n := range notifiers {
+		go func(i int, n Notifier) {
+			results[i] = Result{Notifier: n, Err: notifyWithRetry(ctx, n, a)}
+			done <- struct{}{}
+		}(i, n)
+	}
+	for range notifiers {
+		<-done
+	}
+	return results
+}
+
+// ResolveAll resolves alert against every notifier in notifiers that
+// implements Resolver, skipping the rest. It returns one Result per
+// resolved notifier rather than one per notifier passed in, since most
+// channels have nothing to resolve.
+func ResolveAll(ctx context.Context, notifiers []Notifier, a Alert) []Result {
+	var results []Result
+	for _, n := range notifiers {
+		r, ok := n.(Resolver)
+		if !ok {
+			continue
+		}
+		results = append(results, Result{Notifier: n, Err: r.Resolve(ctx, a)})
+	}
+	return results
+}
+
+const (
+	maxAttempts  = 3
+	initialDelay = 500 * time.Millisecond
+)
+
+// notifyWithRetry retries a single notifier with exponential backoff,
+// bailing out early if ctx is cancelled.
+func notifyWithRetry(ctx context.Context, n Notifier, a Alert) error {
+	delay := initialDelay
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = n.Notify(ctx, a); err == nil {
+			return nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return fmt.Errorf("giving up after %d attempts: %v", maxAttempts, err)
+}
@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyNotifier triggers and resolves incidents via the PagerDuty
+// Events API v2.
+type PagerDutyNotifier struct {
+	RoutingKey string
+	Client     *http.Client
+}
+
+func newPagerDutyNotifier(cfg map[string]string) (*PagerDutyNotifier, error) {
+	key := cfg["routing_key"]
+	if key == "" {
+		return nil, fmt.Errorf("pagerduty channel requires routing_key")
+	}
+	return &PagerDutyNotifier{RoutingKey: key}, nil
+}
+
+// Notify triggers an incident, deduplicated on app+component so repeated
+// checks of the same breach update rather than spawn new incidents.
+func (p *PagerDutyNotifier) Notify(ctx context.Context, a Alert) error {
+	return p.send(ctx, "trigger", a)
+}
+
+// Resolve closes out the incident previously triggered for this app and
+// component, once the owner checks back in.
+func (p *PagerDutyNotifier) Resolve(ctx context.Context, a Alert) error {
+	return p.send(ctx, "resolve", a)
+}
+
+func (p *PagerDutyNotifier) send(ctx context.Context, action string, a Alert) error {
+	event := map[string]interface{}{
+		"routing_key":  p.RoutingKey,
+		"event_action": action,
+		"dedup_key":    a.dedupKey(),
+		"payload": map[string]interface{}{
+			"summary":  fmt.Sprintf("%s/%s overdue by %s (owner: %s)", a.App, a.Component, a.DurationOverdue, a.Owner),
+			"source":   a.App,
+			"severity": "critical",
+		},
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pagerDutyEventsURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty events api returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SlackNotifier posts to a Slack incoming webhook.
+type SlackNotifier struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+func newSlackNotifier(cfg map[string]string) (*SlackNotifier, error) {
+	url := cfg["webhook_url"]
+	if url == "" {
+		return nil, fmt.Errorf("slack channel requires webhook_url")
+	}
+	return &SlackNotifier{WebhookURL: url}, nil
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notify posts a human-readable breach summary to the Slack channel the
+// incoming webhook is bound to.
+func (s *SlackNotifier) Notify(ctx context.Context, a Alert) error {
+	msg := slackMessage{
+		Text: fmt.Sprintf(":rotating_light: *%s/%s* has not checked in since %s (owner: %s, overdue by %s)",
+			a.App, a.Component, a.LastCheckin.Format("Mon Jan 2 15:04:05 MST 2006"), a.Owner, a.DurationOverdue),
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
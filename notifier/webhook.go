@@ -0,0 +1,67 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier posts a JSON payload to an arbitrary HTTP endpoint.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func newWebhookNotifier(cfg map[string]string) (*WebhookNotifier, error) {
+	url := cfg["url"]
+	if url == "" {
+		return nil, fmt.Errorf("webhook channel requires url")
+	}
+	return &WebhookNotifier{URL: url}, nil
+}
+
+type webhookPayload struct {
+	App             string `json:"app"`
+	Component       string `json:"component"`
+	Owner           string `json:"owner"`
+	LastCheckin     string `json:"last_checkin"`
+	DurationOverdue string `json:"duration_overdue"`
+}
+
+// Notify POSTs a JSON payload describing the breach to the configured URL.
+func (w *WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	payload := webhookPayload{
+		App:             a.App,
+		Component:       a.Component,
+		Owner:           a.Owner,
+		LastCheckin:     a.LastCheckin.Format("Mon Jan 2 15:04:05 MST 2006"),
+		DurationOverdue: a.DurationOverdue.String(),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
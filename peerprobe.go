@@ -0,0 +1,152 @@
+package gotel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	peerProbeTimeout     = 2 * time.Second
+	peerProbeWorkers     = 8
+	breakerFailThreshold = 3
+	breakerCooldown      = 30 * time.Second
+
+	// peerAPIPort is the HTTP API port every peer is assumed to listen on.
+	// cluster.Server.Address is the Raft transport bind address (the raft
+	// port), not the HTTP port, so it has to be swapped out before probing,
+	// the same assumption the pre-Raft code's hardcoded ":8080" made.
+	peerAPIPort = 8080
+)
+
+// peerClient is the shared, deadline-aware HTTP client used to probe the
+// raft.Servers the /nodes view wants to annotate with reachability. A
+// bounded idle-connection pool keeps repeated probes of the same peers
+// from reopening a TCP connection every load. ge.peerMu guards the lazy
+// init against concurrent /nodes requests racing to build it.
+func (ge *Endpoint) peerClient() *http.Client {
+	ge.peerMu.Lock()
+	defer ge.peerMu.Unlock()
+	if ge.peerHTTPClient == nil {
+		ge.peerHTTPClient = &http.Client{
+			Timeout: peerProbeTimeout,
+			Transport: &http.Transport{
+				MaxIdleConnsPerHost: 4,
+				IdleConnTimeout:     60 * time.Second,
+			},
+		}
+	}
+	return ge.peerHTTPClient
+}
+
+// breakerFor returns the circuit breaker tracking addr, creating one the
+// first time addr is seen. ge.peerMu guards ge.peerBreakers, since
+// probePeers calls this once per peer from that peer's own worker
+// goroutine.
+func (ge *Endpoint) breakerFor(addr string) *circuitBreaker {
+	ge.peerMu.Lock()
+	defer ge.peerMu.Unlock()
+	if ge.peerBreakers == nil {
+		ge.peerBreakers = map[string]*circuitBreaker{}
+	}
+	b, ok := ge.peerBreakers[addr]
+	if !ok {
+		b = newCircuitBreaker(breakerFailThreshold, breakerCooldown)
+		ge.peerBreakers[addr] = b
+	}
+	return b
+}
+
+// peerHealth is one probed peer's reachability, for display on /nodes.
+type peerHealth struct {
+	Reachable bool
+	Breaker   string
+}
+
+// probePeers checks /is-coordinator on every address concurrently, bounded
+// to peerProbeWorkers in flight at once, skipping any peer whose breaker
+// is currently open. Each response body is closed as soon as it's read
+// rather than deferred to function return, so a long list of peers never
+// leaves a pile of open sockets until probePeers returns.
+func (ge *Endpoint) probePeers(ctx context.Context, addrs []string) map[string]peerHealth {
+	results := make(map[string]peerHealth, len(addrs))
+	var mu sync.Mutex
+	sem := make(chan struct{}, peerProbeWorkers)
+	var wg sync.WaitGroup
+
+	for _, addr := range addrs {
+		breaker := ge.breakerFor(addr)
+		if !breaker.allow() {
+			mu.Lock()
+			results[addr] = peerHealth{Reachable: false, Breaker: breaker.String()}
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(addr string, breaker *circuitBreaker) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			reachable := ge.probeOne(ctx, addr)
+			if reachable {
+				breaker.recordSuccess()
+			} else {
+				breaker.recordFailure()
+			}
+
+			mu.Lock()
+			results[addr] = peerHealth{Reachable: reachable, Breaker: breaker.String()}
+			mu.Unlock()
+		}(addr, breaker)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func (ge *Endpoint) probeOne(ctx context.Context, addr string) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, peerProbeTimeout)
+	defer cancel()
+
+	url, err := peerProbeURL(addr)
+	if err != nil {
+		ge.log().Warn("Unable to derive probe URL for peer [%s] [%v]", addr, err)
+		return false
+	}
+
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, url, nil)
+	if err != nil {
+		ge.log().Warn("Unable to build probe request for peer [%s] [%v]", addr, err)
+		return false
+	}
+
+	resp, err := ge.peerClient().Do(req)
+	if err != nil {
+		ge.log().Warn("Unable to contact peer [%s] assuming offline", addr)
+		return false
+	}
+	defer resp.Body.Close()
+	// Drain the body so the transport can return the connection to its
+	// idle pool instead of closing it.
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode == http.StatusOK
+}
+
+// peerProbeURL builds the HTTP probe URL for addr, a Raft transport
+// address such as "10.0.0.5:7000": the host survives, but the port is
+// replaced with peerAPIPort since nothing HTTP is listening on the raft
+// port itself.
+func peerProbeURL(addr string) (string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("http://%s:%d/is-coordinator", host, peerAPIPort), nil
+}
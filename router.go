@@ -0,0 +1,534 @@
+package gotel
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etsangsplk/gotel/metrics"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// middleware wraps a handler to add cross-cutting behavior such as request
+// logging, panic recovery, metrics, or authentication.
+type middleware func(http.Handler) http.Handler
+
+// chain applies mws around h in order, so the first middleware passed in
+// runs outermost (it sees the request first and the response last).
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+type paramsKey struct{}
+
+// pathParam returns the named path parameter extracted for this request by
+// route, or "" if it isn't present.
+func pathParam(req *http.Request, name string) string {
+	params, _ := req.Context().Value(paramsKey{}).(map[string]string)
+	return params[name]
+}
+
+type routePatternKey struct{}
+
+// newRoutePatternHolder attaches an empty *string to req's context for
+// route to fill in with the pattern it matched, and hands the holder back
+// so metricsMiddleware can read it after ServeHTTP returns. A holder is
+// needed, rather than a plain context value, because route() only ever
+// sees the *http.Request metricsMiddleware passed to next.ServeHTTP; the
+// context.WithValue it adds for the matched pattern lives on a copy that
+// never propagates back up to metricsMiddleware's own req variable.
+func newRoutePatternHolder(req *http.Request) (*http.Request, *string) {
+	holder := new(string)
+	return req.WithContext(context.WithValue(req.Context(), routePatternKey{}, holder)), holder
+}
+
+// setRoutePattern records pattern into the holder newRoutePatternHolder
+// attached to req's context, if any (i.e. the request actually came in
+// through Handler(), not a handler called directly in a test).
+func setRoutePattern(req *http.Request, pattern string) {
+	if holder, ok := req.Context().Value(routePatternKey{}).(*string); ok {
+		*holder = pattern
+	}
+}
+
+// route binds method and a pattern such as "/reservation/:app/:component"
+// to fn, populating path parameters into the request context before fn
+// runs. A method mismatch writes a 400; a segment-count mismatch (the
+// pattern matched ServeMux's prefix but not the full path) falls through
+// to http.NotFound instead.
+func route(mux *http.ServeMux, method, pattern string, fn http.HandlerFunc) {
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	mux.HandleFunc(routePrefix(pattern), func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != method {
+			writeError(w, fmt.Sprintf("Invalid method %s", req.Method))
+			return
+		}
+		reqSegments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		if len(reqSegments) != len(segments) {
+			http.NotFound(w, req)
+			return
+		}
+		params := make(map[string]string, len(segments))
+		for i, seg := range segments {
+			if strings.HasPrefix(seg, ":") {
+				params[strings.TrimPrefix(seg, ":")] = reqSegments[i]
+			} else if seg != reqSegments[i] {
+				http.NotFound(w, req)
+				return
+			}
+		}
+		req = req.WithContext(context.WithValue(req.Context(), paramsKey{}, params))
+		setRoutePattern(req, pattern)
+		fn(w, req)
+	})
+}
+
+// routePrefix turns a pattern with named segments into the fixed prefix
+// ServeMux needs to register on, e.g. "/reservation/:app/:component"
+// becomes "/reservation/".
+func routePrefix(pattern string) string {
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			return "/" + strings.Join(segments[:i], "/") + "/"
+		}
+	}
+	return pattern
+}
+
+type requestIDKey struct{}
+
+// requestID returns the correlation ID requestIDMiddleware attached to
+// req's context, or "" if the middleware hasn't run (e.g. in a test that
+// calls a handler directly).
+func requestID(req *http.Request) string {
+	id, _ := req.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDMiddleware stamps every request with a correlation ID, so a
+// user who hits an error can give us one string to grep the server logs
+// for instead of a timestamp and a guess.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get("X-Request-ID")
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		req = req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id))
+		next.ServeHTTP(w, req)
+	})
+}
+
+// requestFields builds the structured log fields (app, component, owner,
+// request_id, remote_addr, duration_ms) shared by the handlers this
+// request restructured: makeReservation, doCheckin, doSnooze, doCheckOut,
+// and listReservations. duration_ms is measured from start at the moment
+// requestFields is called, so call it right before each log line rather
+// than once up front.
+func requestFields(req *http.Request, app, component, owner string, start time.Time) []any {
+	return []any{
+		slog.String("app", app),
+		slog.String("component", component),
+		slog.String("owner", owner),
+		slog.String("request_id", requestID(req)),
+		slog.String("remote_addr", req.RemoteAddr),
+		slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// loggingMiddleware logs the method, path, status, duration, and
+// correlation ID of every request, through ge's own logger.
+func loggingMiddleware(ge *Endpoint) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, req)
+			ge.log().Structured(req.Context(), slog.LevelInfo, "request",
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.Int("status", sw.status),
+				slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+				slog.String("request_id", requestID(req)),
+				slog.String("remote_addr", req.RemoteAddr),
+			)
+		})
+	}
+}
+
+// metricsMiddleware observes gotel_http_request_duration_seconds for every
+// request, labeled by route, method, and status. It labels on the matched
+// route pattern rather than the raw path, so /reservation/:app/:component
+// is one time series instead of one per app/component pair ever seen.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		req, pattern := newRoutePatternHolder(req)
+		next.ServeHTTP(sw, req)
+		label := *pattern
+		if label == "" {
+			label = req.URL.Path
+		}
+		metrics.HTTPRequestDuration.WithLabelValues(label, req.Method, strconv.Itoa(sw.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// recoveryMiddleware turns a panic in a handler into a 500 response instead
+// of taking down the whole server.
+func recoveryMiddleware(ge *Endpoint) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer func() {
+				if rec := recover(); rec != nil {
+					ge.log().Err("panic handling %s %s: %v", req.Method, req.URL.Path, rec)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					_, _ = w.Write([]byte(`"Internal server error"`))
+				}
+			}()
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// statusWriter records the status code written so middleware can log it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// authMiddleware enforces basic auth or a bearer token against the users
+// table. Requests to paths in publicPaths are let through unauthenticated,
+// and so is everything else until an operator has actually provisioned a
+// user: ensureUsersTable only creates an empty table, and this series adds
+// no user-provisioning endpoint, so enforcing auth unconditionally against
+// an empty table would lock a fresh deployment out of its own /status and
+// break every existing agent's /checkin on upgrade, with no way for either
+// to ever obtain a credential.
+func authMiddleware(ge *Endpoint, publicPaths map[string]bool) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if publicPaths[req.URL.Path] || !ge.authConfigured() {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if user, pass, ok := req.BasicAuth(); ok {
+				if ge.checkBasicAuth(user, pass) {
+					next.ServeHTTP(w, req)
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Basic realm="gotel"`)
+				writeError(w, "Invalid credentials")
+				return
+			}
+
+			if token := bearerToken(req); token != "" {
+				if ge.checkBearerToken(token) {
+					next.ServeHTTP(w, req)
+					return
+				}
+				writeError(w, "Invalid token")
+				return
+			}
+
+			w.Header().Set("WWW-Authenticate", `Basic realm="gotel"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+}
+
+func bearerToken(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if strings.HasPrefix(auth, prefix) {
+		return strings.TrimPrefix(auth, prefix)
+	}
+	return ""
+}
+
+// authConfigured reports whether any user has been provisioned yet. Until
+// one has, authMiddleware lets every request through rather than enforcing
+// credentials nobody has been given a way to obtain.
+func (ge *Endpoint) authConfigured() bool {
+	if ge.Db == nil {
+		return false
+	}
+	var count int
+	if err := ge.Db.QueryRow("SELECT count(*) FROM users").Scan(&count); err != nil {
+		ge.log().Err("Unable to check whether auth is configured [%v]", err)
+		return false
+	}
+	return count > 0
+}
+
+// checkBasicAuth looks up user's password_hash in the users table and
+// compares pass against it with bcrypt, rather than storing or matching
+// the password itself.
+func (ge *Endpoint) checkBasicAuth(user, pass string) bool {
+	var hash string
+	err := ge.Db.QueryRow("SELECT password_hash FROM users WHERE username = ?", user).Scan(&hash)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			ge.log().Err("Unable to check basic auth for user %s [%v]", user, err)
+		}
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) == nil
+}
+
+// checkBearerToken looks up token against the users table.
+func (ge *Endpoint) checkBearerToken(token string) bool {
+	var count int
+	err := ge.Db.QueryRow("SELECT count(*) FROM users WHERE token = ?", token).Scan(&count)
+	if err != nil {
+		ge.log().Err("Unable to check bearer token [%v]", err)
+		return false
+	}
+	return count == 1
+}
+
+// writePaths are the routes that mutate replicated state and therefore
+// must run on the Raft leader.
+var writePaths = map[string]bool{
+	"/reservation":     true,
+	"/checkin":         true,
+	"/checkout":        true,
+	"/snooze":          true,
+	"/snooze/schedule": true,
+	"/cluster/join":    true,
+	"/cluster/remove":  true,
+}
+
+// leaderForwardMiddleware redirects writes to the Raft leader with a 307,
+// which preserves the method and body, so a non-leader never tries to
+// apply a command the cluster hasn't agreed it's the one to apply.
+func leaderForwardMiddleware(ge *Endpoint) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// GETs on write paths (listing reservations or schedules) are
+			// served locally, except strong-consistency reads, which need the
+			// leader's Barrier.
+			isRead := req.Method == http.MethodGet && req.URL.Query().Get("consistency") != "strong"
+			if isRead || !isWritePath(req.URL.Path) || ge.Cluster == nil || ge.Cluster.IsLeader() {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			leader := ge.Cluster.Leader()
+			if leader == "" {
+				writeError(w, "No cluster leader available")
+				return
+			}
+			http.Redirect(w, req, fmt.Sprintf("http://%s%s", leader, req.URL.RequestURI()), http.StatusTemporaryRedirect)
+		})
+	}
+}
+
+func isWritePath(path string) bool {
+	if writePaths[path] {
+		return true
+	}
+	// Path-parameterized shortcuts, e.g. /reservation/:app/:component.
+	for prefix := range map[string]bool{"/reservation/": true, "/checkin/": true} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler builds the full request-handling chain: the routed mux wrapped
+// in recovery, logging, and authentication middleware. Tests and embedders
+// can call this directly with httptest rather than binding a port via
+// InitAPI.
+func (ge *Endpoint) Handler(htmlPath string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			r := Response{"success": true, "message": "A-OK!"}
+			writeResponse(w, r)
+		}
+	})
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			ge.renderView(w, htmlPath+"/public/view.html", ge.getReservations)
+		}
+	})
+
+	mux.HandleFunc("/badguests", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			ge.renderView(w, htmlPath+"/public/badguests.html", ge.getBadGuests)
+		}
+	})
+
+	mux.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			ge.renderView(w, htmlPath+"/public/nodes.html", ge.getNodes)
+		}
+	})
+
+	mux.HandleFunc("/reservation", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			ge.listReservations(w, r)
+			return
+		} else if r.Method == "POST" {
+			ge.makeReservation(w, r)
+			return
+		}
+		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+	})
+	route(mux, "POST", "/reservation/:app/:component", ge.makeReservationPath)
+
+	mux.HandleFunc("/checkin", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			ge.doCheckin(w, r)
+			return
+		}
+		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+	})
+	route(mux, "POST", "/checkin/:app/:component", ge.doCheckinPath)
+
+	mux.HandleFunc("/checkout", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			ge.doCheckOut(w, r)
+			return
+		}
+		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+	})
+	mux.HandleFunc("/snooze", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			ge.doSnooze(w, r)
+			return
+		}
+		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+	})
+	mux.HandleFunc("/snooze/schedule", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			ge.doSnoozeScheduleCreate(w, r)
+		case "DELETE":
+			ge.doSnoozeScheduleDelete(w, r)
+		case "GET":
+			ge.doSnoozeScheduleList(w, r)
+		default:
+			writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+		}
+	})
+	mux.HandleFunc("/notify/test", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			ge.doNotifyTest(w, r)
+			return
+		}
+		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+	})
+	mux.HandleFunc("/is-coordinator", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			ge.isCoordinator(w, r)
+			return
+		}
+		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+	})
+	mux.HandleFunc("/cluster/join", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			ge.doClusterJoin(w, r)
+			return
+		}
+		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+	})
+	mux.HandleFunc("/cluster/remove", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			ge.doClusterRemove(w, r)
+			return
+		}
+		writeError(w, fmt.Sprintf("Invalid method %s", r.Method))
+	})
+	mux.Handle("/metrics", promhttp.Handler())
+
+	publicPaths := map[string]bool{"/": true, "/is-coordinator": true, "/metrics": true}
+	return chain(mux, requestIDMiddleware, recoveryMiddleware(ge), loggingMiddleware(ge), metricsMiddleware, authMiddleware(ge, publicPaths), leaderForwardMiddleware(ge))
+}
+
+// renderView runs fn and executes tmplPath against the result, following
+// the same error-to-JSON fallback the view handlers have always used.
+func (ge *Endpoint) renderView(w http.ResponseWriter, tmplPath string, fn interface{}) {
+	var data interface{}
+	var err error
+	switch f := fn.(type) {
+	case func() ([]reservation, error):
+		data, err = f()
+	case func() ([]badGuest, error):
+		data, err = f()
+	case func() ([]node, error):
+		data, err = f()
+	}
+	if err != nil {
+		ge.log().Err(err.Error())
+		r := Response{"success": false, "message": "Unable to server views"}
+		writeResponse(w, r)
+		return
+	}
+
+	t, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		ge.log().Err(err.Error())
+		return
+	}
+	if err := t.Execute(w, data); err != nil {
+		ge.log().Err(err.Error())
+	}
+}
+
+// makeReservationPath is the /reservation/:app/:component shortcut: the
+// body carries everything except app and component, which come from the
+// path.
+func (ge *Endpoint) makeReservationPath(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	res := new(reservation)
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&res); err != nil {
+		ge.log().Err("Unable to accept reservation")
+	}
+	res.App = pathParam(req, "app")
+	res.Component = pathParam(req, "component")
+	ge.storeAndRespondReservation(w, req, res, start)
+}
+
+// doCheckinPath is the /checkin/:app/:component shortcut for callers that
+// don't want to build a JSON body just to check in.
+func (ge *Endpoint) doCheckinPath(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	c := &checkin{App: pathParam(req, "app"), Component: pathParam(req, "component")}
+	ge.storeAndRespondCheckin(w, req, c, start)
+}
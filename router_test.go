@@ -0,0 +1,185 @@
+package gotel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoute_ExtractsPathParams(t *testing.T) {
+	var gotApp, gotComponent string
+	mux := http.NewServeMux()
+	route(mux, http.MethodPost, "/reservation/:app/:component", func(w http.ResponseWriter, req *http.Request) {
+		gotApp = pathParam(req, "app")
+		gotComponent = pathParam(req, "component")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/reservation/billing/worker", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if gotApp != "billing" || gotComponent != "worker" {
+		t.Fatalf("pathParam app/component = %q/%q, want billing/worker", gotApp, gotComponent)
+	}
+}
+
+func TestRoute_RejectsWrongMethod(t *testing.T) {
+	called := false
+	mux := http.NewServeMux()
+	route(mux, http.MethodPost, "/reservation/:app/:component", func(w http.ResponseWriter, req *http.Request) {
+		called = true
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/reservation/billing/worker", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if called {
+		t.Fatal("handler should not run for a method mismatch")
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestRoute_SetsMatchedPatternForMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	handler := metricsMiddleware(mux)
+	route(mux, http.MethodPost, "/reservation/:app/:component", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/reservation/billing/worker", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	// metricsMiddleware observes a Prometheus histogram rather than
+	// returning a value, so the call above only exercises the holder
+	// plumbing end-to-end without panicking. The label it reads is checked
+	// directly through a holder below.
+	req, pattern := newRoutePatternHolder(httptest.NewRequest(http.MethodPost, "/reservation/billing/worker", nil))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	if *pattern != "/reservation/:app/:component" {
+		t.Fatalf("matched pattern = %q, want %q", *pattern, "/reservation/:app/:component")
+	}
+}
+
+func TestIsWritePath(t *testing.T) {
+	cases := map[string]bool{
+		"/reservation":                true,
+		"/reservation/billing/worker": true,
+		"/checkin":                    true,
+		"/checkin/billing/worker":     true,
+		"/checkout":                   true,
+		"/snooze":                     true,
+		"/snooze/schedule":            true,
+		"/cluster/join":               true,
+		"/cluster/remove":             true,
+		"/status":                     false,
+		"/nodes":                      false,
+		"/is-coordinator":             false,
+		"/":                           false,
+	}
+	for path, want := range cases {
+		if got := isWritePath(path); got != want {
+			t.Errorf("isWritePath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestRequestIDMiddleware_GeneratesAndEchoesHeader(t *testing.T) {
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		seen = requestID(req)
+	})
+	handler := requestIDMiddleware(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if seen == "" {
+		t.Fatal("expected a generated request ID to reach the handler")
+	}
+	if rec.Header().Get("X-Request-ID") != seen {
+		t.Fatalf("X-Request-ID header = %q, want %q", rec.Header().Get("X-Request-ID"), seen)
+	}
+}
+
+func TestRequestIDMiddleware_PreservesIncomingID(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	handler := requestIDMiddleware(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "caller-supplied-id")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got != "caller-supplied-id" {
+		t.Fatalf("X-Request-ID = %q, want the caller-supplied value", got)
+	}
+}
+
+func TestAuthMiddleware_PublicPathBypassesAuth(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { called = true })
+	handler := authMiddleware(&Endpoint{}, map[string]bool{"/": true})(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("expected a public path to reach the handler without credentials")
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestAuthMiddleware_SkipsEnforcementWhenNoUsersConfigured(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { called = true })
+	handler := authMiddleware(&Endpoint{}, map[string]bool{})(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/reservation", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("expected a request to reach the handler when no users are configured")
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestLeaderForwardMiddleware_NilClusterPassesThrough(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { called = true })
+	handler := leaderForwardMiddleware(&Endpoint{})(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/reservation", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("expected a write request to reach the handler when no Cluster is configured")
+	}
+}
+
+func TestLeaderForwardMiddleware_ReadPathPassesThroughRegardlessOfCluster(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { called = true })
+	handler := leaderForwardMiddleware(&Endpoint{})(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("expected a non-write path to reach the handler unconditionally")
+	}
+}
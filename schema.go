@@ -0,0 +1,65 @@
+package gotel
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// ensureNotifyChannelsColumn adds the notify_channels column the notifier
+// package needs, if an older database doesn't already have it. There's no
+// portable "ADD COLUMN IF NOT EXISTS" across the drivers gotel might run
+// against, so we probe for the column instead of assuming a fresh migration
+// was run out-of-band.
+func ensureNotifyChannelsColumn(db *sql.DB) error {
+	rows, err := db.Query("SELECT notify_channels FROM reservations LIMIT 0")
+	if err == nil {
+		return rows.Close()
+	}
+	_, err = db.Exec("ALTER TABLE reservations ADD COLUMN notify_channels TEXT")
+	return err
+}
+
+// ensureUsersTable creates the users table authMiddleware checks credentials
+// against, if it doesn't already exist: username/password_hash for basic
+// auth, token for bearer auth. CREATE TABLE IF NOT EXISTS is portable across
+// the drivers gotel might run against, unlike the ADD COLUMN probe above.
+func ensureUsersTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS users (
+		username TEXT PRIMARY KEY,
+		password_hash TEXT NOT NULL DEFAULT '',
+		token TEXT
+	)`)
+	return err
+}
+
+// ensureSnoozeSchedulesTable creates the snooze_schedules table the
+// snoozeschedule.go recurring-window feature reads and writes, if it
+// doesn't already exist. id has no AUTOINCREMENT: it's assigned by
+// newScheduleID before replication so every node's FSM inserts the same
+// value for a given schedule instead of each assigning its own.
+func ensureSnoozeSchedulesTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS snooze_schedules (
+		id INTEGER PRIMARY KEY,
+		app TEXT NOT NULL,
+		component TEXT NOT NULL,
+		schedule TEXT NOT NULL,
+		window_duration TEXT NOT NULL,
+		tz TEXT,
+		next_fire_at INTEGER
+	)`)
+	return err
+}
+
+// persistNotifyChannels marshals res.NotifyChannels and writes it back onto
+// its row. storeReservation's own INSERT/UPDATE predates notify_channels,
+// so this runs as a second write right after it, keyed on the same
+// app/component storeReservation just wrote.
+func persistNotifyChannels(db *sql.DB, res *reservation) error {
+	channels, err := json.Marshal(res.NotifyChannels)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("UPDATE reservations SET notify_channels = ? WHERE app = ? AND component = ?",
+		string(channels), res.App, res.Component)
+	return err
+}
@@ -0,0 +1,89 @@
+package gotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/etsangsplk/gotel/metrics"
+	"github.com/etsangsplk/gotel/notifier"
+)
+
+// RunSLAChecker periodically re-evaluates every reservation's SLA and fans
+// any breach out through its configured notifiers. It runs until ctx is
+// cancelled, so callers should launch it with `go`, the same as
+// RunSnoozeScheduler.
+func (ge *Endpoint) RunSLAChecker(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ge.checkSLAs(ctx)
+		}
+	}
+}
+
+// checkSLAs loads the current reservations and notifies on every one
+// failing its SLA. Only the coordinator notifies: every node runs this
+// loop, but a follower skips the fan-out so a breach doesn't page the same
+// owner once per voter, and a fresh leader picks the loop straight back up
+// after failover instead of leaving a gap.
+//
+// This is also the only place gotel_sla_failures_total is incremented: it
+// counts breaches this evaluator observed, not every time a reservation's
+// FailingSLA flag is read (e.g. by /status or GET /reservation), so it
+// doesn't climb every time someone loads the UI.
+func (ge *Endpoint) checkSLAs(ctx context.Context) {
+	if ge.Cluster != nil && !ge.Cluster.IsLeader() {
+		return
+	}
+
+	reservations, err := ge.getReservations()
+	if err != nil {
+		ge.log().Err("Unable to load reservations for SLA check [%v]", err)
+		return
+	}
+	for _, res := range reservations {
+		if res.FailingSLA {
+			metrics.SLAFailuresTotal.WithLabelValues(res.App, res.Component).Inc()
+			ge.notifySLABreach(ctx, res)
+		}
+	}
+}
+
+// notifySLABreach fans a breach alert out to res's configured notifiers,
+// the same fan-out doNotifyTest uses to validate the wiring.
+func (ge *Endpoint) notifySLABreach(ctx context.Context, res reservation) {
+	// getReservations doesn't load notify_channels, so fetch the full row.
+	full, err := ge.getReservation(res.App, res.Component)
+	if err != nil {
+		ge.log().Err("Unable to load notify channels for %s/%s [%v]", res.App, res.Component, err)
+		return
+	}
+	notifiers, err := notifier.Build(full.NotifyChannels)
+	if err != nil {
+		ge.log().Err("Unable to build notifiers for %s/%s [%v]", res.App, res.Component, err)
+		return
+	}
+	if len(notifiers) == 0 {
+		return
+	}
+
+	alert := notifier.Alert{
+		App:             res.App,
+		Component:       res.Component,
+		Owner:           res.Owner,
+		LastCheckin:     time.Unix(res.LastCheckin, 0),
+		DurationOverdue: time.Since(time.Unix(res.LastCheckin, 0)),
+	}
+
+	notifyCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for _, result := range notifier.FanOut(notifyCtx, notifiers, alert) {
+		if result.Err != nil {
+			ge.log().Warn("SLA alert failed for %s/%s [%v]", res.App, res.Component, result.Err)
+		}
+	}
+}
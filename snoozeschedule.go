@@ -0,0 +1,285 @@
+package gotel
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// snoozeSchedule is a recurring maintenance window: alerts for App/Component
+// are suppressed whenever now falls within WindowDuration of a firing of
+// Schedule. TZ is applied to Schedule as a robfig/cron "CRON_TZ=" prefix so
+// windows can track local business hours instead of UTC; left empty, it
+// defaults to UTC rather than whatever zone the host happens to be in, so
+// e.g. "Sunday 02:00-04:00 UTC" means the same thing on every node.
+type snoozeSchedule struct {
+	ID             int64  `json:"id"`
+	App            string `json:"app"`
+	Component      string `json:"component"`
+	Schedule       string `json:"schedule"`
+	WindowDuration string `json:"window_duration"`
+	TZ             string `json:"tz"`
+	NextFireAt     int64  `json:"next_fire_at"`
+}
+
+// parsedSchedule holds a schedule row alongside its parsed cron.Schedule
+// and window, ready for isActiveWindow checks.
+type parsedSchedule struct {
+	snoozeSchedule
+	cron   cron.Schedule
+	window time.Duration
+}
+
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+func parseSnoozeSchedule(s snoozeSchedule) (*parsedSchedule, error) {
+	tz := s.TZ
+	if tz == "" {
+		tz = "UTC"
+	}
+	spec := fmt.Sprintf("CRON_TZ=%s %s", tz, s.Schedule)
+	schedule, err := cronParser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %v", s.Schedule, err)
+	}
+	window, err := time.ParseDuration(s.WindowDuration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window_duration %q: %v", s.WindowDuration, err)
+	}
+	return &parsedSchedule{snoozeSchedule: s, cron: schedule, window: window}, nil
+}
+
+// isActiveWindow reports whether now falls inside the window opened by the
+// most recent firing of p before now.
+func (p *parsedSchedule) isActiveWindow(now time.Time) bool {
+	prev := p.cron.Next(now.Add(-p.window))
+	return !prev.After(now) && now.Before(prev.Add(p.window))
+}
+
+// newScheduleID generates a schedule ID before replication, the same
+// crypto/rand approach router.go's newRequestID uses for request IDs, so
+// every replica's FSM inserts the identical row instead of each node's own
+// SQLite autoincrement assigning a different ID to what's meant to be one
+// schedule.
+func newScheduleID() (int64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) & math.MaxInt64), nil
+}
+
+// storeSnoozeSchedule persists p, which must already have its ID (see
+// newScheduleID) and NextFireAt set so the row is identical on every
+// replica that applies it. A zero NextFireAt is computed from this node's
+// clock.
+func storeSnoozeSchedule(db *sql.DB, p *parsedSchedule) error {
+	if p.NextFireAt == 0 {
+		p.NextFireAt = p.cron.Next(time.Now()).Unix()
+	}
+	_, err := db.Exec(
+		"INSERT INTO snooze_schedules (id, app, component, schedule, window_duration, tz, next_fire_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		p.ID, p.App, p.Component, p.Schedule, p.WindowDuration, p.TZ, p.NextFireAt)
+	return err
+}
+
+func deleteSnoozeSchedule(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM snooze_schedules WHERE id = ?", id)
+	return err
+}
+
+func listSnoozeSchedules(db *sql.DB, app, component string) ([]snoozeSchedule, error) {
+	rows, err := db.Query(
+		"SELECT id, app, component, schedule, window_duration, tz, next_fire_at FROM snooze_schedules WHERE app = ? AND component = ? ORDER BY next_fire_at",
+		app, component)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []snoozeSchedule{}
+	for rows.Next() {
+		s := snoozeSchedule{}
+		if err := rows.Scan(&s.ID, &s.App, &s.Component, &s.Schedule, &s.WindowDuration, &s.TZ, &s.NextFireAt); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+func loadParsedSchedules(db *sql.DB) ([]*parsedSchedule, error) {
+	rows, err := db.Query("SELECT id, app, component, schedule, window_duration, tz, next_fire_at FROM snooze_schedules")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parsed := []*parsedSchedule{}
+	for rows.Next() {
+		s := snoozeSchedule{}
+		if err := rows.Scan(&s.ID, &s.App, &s.Component, &s.Schedule, &s.WindowDuration, &s.TZ, &s.NextFireAt); err != nil {
+			return nil, err
+		}
+		p, err := parseSnoozeSchedule(s)
+		if err != nil {
+			l.Warn("Skipping unparseable snooze schedule %d for %s/%s [%v]", s.ID, s.App, s.Component, err)
+			continue
+		}
+		parsed = append(parsed, p)
+	}
+	return parsed, nil
+}
+
+// scheduleIndex groups parsed schedules by app/component, so a caller that
+// needs to check many reservations against the same schedule set (e.g.
+// getReservations evaluating every row) can do it with one load instead of
+// re-querying and re-parsing every schedule per reservation.
+type scheduleIndex map[string][]*parsedSchedule
+
+func scheduleKey(app, component string) string {
+	return app + "/" + component
+}
+
+// buildScheduleIndex loads every snooze schedule once and indexes it by
+// app/component.
+func buildScheduleIndex(db *sql.DB) (scheduleIndex, error) {
+	schedules, err := loadParsedSchedules(db)
+	if err != nil {
+		return nil, err
+	}
+	idx := make(scheduleIndex, len(schedules))
+	for _, p := range schedules {
+		key := scheduleKey(p.App, p.Component)
+		idx[key] = append(idx[key], p)
+	}
+	return idx, nil
+}
+
+// isSnoozedByIndex reports whether app/component currently falls inside one
+// of its active recurring maintenance windows in idx.
+func isSnoozedByIndex(idx scheduleIndex, app, component string, now time.Time) bool {
+	for _, p := range idx[scheduleKey(app, component)] {
+		if p.isActiveWindow(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunSnoozeScheduler keeps next_fire_at current for every schedule,
+// recomputing it once per tick so /snooze/schedule's GET can report
+// upcoming windows without recalculating cron math on every request. It
+// runs until ctx is cancelled, so callers should launch it with `go`.
+func (ge *Endpoint) RunSnoozeScheduler(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			schedules, err := loadParsedSchedules(ge.Db)
+			if err != nil {
+				ge.log().Err("Unable to load snooze schedules [%v]", err)
+				continue
+			}
+			now := time.Now()
+			for _, p := range schedules {
+				next := p.cron.Next(now).Unix()
+				if _, err := ge.Db.Exec("UPDATE snooze_schedules SET next_fire_at = ? WHERE id = ?", next, p.ID); err != nil {
+					ge.log().Err("Unable to update next_fire_at for schedule %d [%v]", p.ID, err)
+				}
+			}
+		}
+	}
+}
+
+// doSnoozeScheduleCreate handles POST /snooze/schedule: validates the cron
+// spec and window, replicates the schedule through the cluster, and
+// returns its ID and computed next_fire_at.
+func (ge *Endpoint) doSnoozeScheduleCreate(w http.ResponseWriter, req *http.Request) {
+	s := new(snoozeSchedule)
+	if err := json.NewDecoder(req.Body).Decode(s); err != nil {
+		writeError(w, fmt.Sprintf("Unable to decode snooze schedule: %v", err))
+		return
+	}
+
+	id, err := newScheduleID()
+	if err != nil {
+		ge.log().Err("Unable to generate snooze schedule ID [%v]", err)
+		writeError(w, "Unable to store snooze schedule")
+		return
+	}
+	s.ID = id
+
+	parsed, err := parseSnoozeSchedule(*s)
+	if err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	parsed.NextFireAt = parsed.cron.Next(time.Now()).Unix()
+
+	if err := ge.applySnoozeScheduleCreate(parsed); err != nil {
+		ge.log().Err("Unable to store snooze schedule for %s/%s [%v]", s.App, s.Component, err)
+		writeError(w, "Unable to store snooze schedule")
+		return
+	}
+
+	writeResponse(w, Response{"success": true, "id": id, "next_fire_at": parsed.NextFireAt})
+}
+
+// doSnoozeScheduleDelete handles DELETE /snooze/schedule?id=NN: cancels a
+// recurring maintenance window.
+func (ge *Endpoint) doSnoozeScheduleDelete(w http.ResponseWriter, req *http.Request) {
+	id, err := parseScheduleID(req)
+	if err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	if err := ge.applySnoozeScheduleDelete(id); err != nil {
+		ge.log().Err("Unable to delete snooze schedule %d [%v]", id, err)
+		writeError(w, "Unable to delete snooze schedule")
+		return
+	}
+	writeResponse(w, Response{"success": true, "message": fmt.Sprintf("Cancelled snooze schedule %d", id)})
+}
+
+// doSnoozeScheduleList handles GET /snooze/schedule?app=X&component=Y:
+// lists upcoming windows for the given app/component.
+func (ge *Endpoint) doSnoozeScheduleList(w http.ResponseWriter, req *http.Request) {
+	app := req.URL.Query().Get("app")
+	component := req.URL.Query().Get("component")
+	if app == "" || component == "" {
+		writeError(w, "app and component query params are required")
+		return
+	}
+
+	schedules, err := listSnoozeSchedules(ge.Db, app, component)
+	if err != nil {
+		ge.log().Err("Unable to list snooze schedules for %s/%s [%v]", app, component, err)
+		writeError(w, "Unable to list snooze schedules")
+		return
+	}
+	writeResponse(w, Response{"success": true, "result": schedules})
+}
+
+func parseScheduleID(req *http.Request) (int64, error) {
+	raw := req.URL.Query().Get("id")
+	if raw == "" {
+		return 0, fmt.Errorf("id query param is required")
+	}
+	var id int64
+	if _, err := fmt.Sscanf(raw, "%d", &id); err != nil {
+		return 0, fmt.Errorf("invalid id %q", raw)
+	}
+	return id, nil
+}
@@ -0,0 +1,56 @@
+package gotel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSnoozeSchedule_InvalidCronIsRejected(t *testing.T) {
+	_, err := parseSnoozeSchedule(snoozeSchedule{
+		Schedule:       "not a cron expression",
+		WindowDuration: "1h",
+	})
+	if err == nil {
+		t.Fatal("expected an error for an invalid cron schedule")
+	}
+}
+
+func TestParseSnoozeSchedule_InvalidWindowIsRejected(t *testing.T) {
+	_, err := parseSnoozeSchedule(snoozeSchedule{
+		Schedule:       "0 2 * * *",
+		WindowDuration: "not a duration",
+	})
+	if err == nil {
+		t.Fatal("expected an error for an invalid window_duration")
+	}
+}
+
+func TestIsActiveWindow(t *testing.T) {
+	// Fires at the top of every hour, with a 30 minute maintenance window.
+	p, err := parseSnoozeSchedule(snoozeSchedule{
+		Schedule:       "0 * * * *",
+		WindowDuration: "30m",
+	})
+	if err != nil {
+		t.Fatalf("parseSnoozeSchedule: %v", err)
+	}
+
+	base := time.Date(2026, time.July, 28, 14, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"at the firing instant", base, true},
+		{"inside the window", base.Add(15 * time.Minute), true},
+		{"at the window edge", base.Add(30 * time.Minute), false},
+		{"outside the window", base.Add(45 * time.Minute), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := p.isActiveWindow(c.now); got != c.want {
+				t.Errorf("isActiveWindow(%s) = %v, want %v", c.now, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package gotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer instruments applyReservation/applyCheckin, the replication calls
+// behind reservations and checkins, so operators can trace a slow request
+// through validation and the write path. It does not reach the SQL itself:
+// with a Cluster configured that write is a Raft apply that may commit on
+// this node's FSM or (after a leader change) another one's, outside this
+// request's span entirely. It's a no-op tracer until InitTracing is called
+// with a collector endpoint.
+var tracer = otel.Tracer("github.com/etsangsplk/gotel")
+
+// InitTracing points gotel's spans at the OTLP collector at otlpEndpoint,
+// returning a shutdown func the caller should defer. The endpoint is
+// supplied by the caller; this package defines no command-line flag for
+// it. An empty endpoint leaves the global no-op tracer in place.
+func InitTracing(ctx context.Context, otlpEndpoint string) (func(context.Context) error, error) {
+	if otlpEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(otlpEndpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName("gotel")))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	tracer = provider.Tracer("github.com/etsangsplk/gotel")
+
+	return provider.Shutdown, nil
+}
+
+// traced runs fn inside a span named name, recording an error status if fn
+// fails.
+func traced(ctx context.Context, name string, fn func(context.Context) error) error {
+	ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	if err := fn(ctx); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}